@@ -0,0 +1,16 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package command
+
+import (
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Register registers the application command line flags and
+// sub-commands.
+func Register(app *kingpin.Application) {
+	registerExec(app)
+	registerSign(app)
+}
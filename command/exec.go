@@ -7,15 +7,18 @@ package command
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/drone-runners/drone-runner-kube/command/internal"
 	"github.com/drone-runners/drone-runner-kube/engine"
+	"github.com/drone-runners/drone-runner-kube/engine/backend"
 	"github.com/drone-runners/drone-runner-kube/engine/compiler"
 	"github.com/drone-runners/drone-runner-kube/engine/linter"
 	"github.com/drone-runners/drone-runner-kube/engine/policy"
@@ -45,6 +48,9 @@ type execCommand struct {
 	Source     *os.File
 	KubeConfig string
 
+	Backend    string
+	DockerHost string
+
 	Include []string
 	Exclude []string
 
@@ -59,6 +65,32 @@ type execCommand struct {
 
 	Policy string
 
+	QoSClass string
+
+	VerifyKey       string
+	VerifySignature string
+
+	NodeSelector       map[string]string
+	Tolerations        []string
+	TolerateTaints     []string
+	NodeAffinity       map[string]string
+	TopologySpread     []string
+	RuntimeClassName   string
+	PriorityClassName  string
+	ServiceAccountName string
+
+	ConfigFile string
+
+	SecretEndpoint      string
+	SecretToken         string
+	SecretSkipVerify    bool
+	secretSkipVerifySet bool
+
+	RegistryEndpoint      string
+	RegistryToken         string
+	RegistrySkipVerify    bool
+	registrySkipVerifySet bool
+
 	Tmate compiler.Tmate
 
 	Clone  bool
@@ -73,6 +105,11 @@ type execCommand struct {
 
 	Engine struct {
 		ContainerStartTimeout int
+
+		Mode                       string
+		JobBackoffLimit            int32
+		JobTTLSecondsAfterFinished int32
+		JobActiveDeadlineSeconds   int64
 	}
 
 	KubeClient kube.ClientConfig
@@ -82,6 +119,8 @@ func (c *execCommand) run(*kingpin.ParseContext) error {
 	// resource memory amounts are provided in megabytes, so convert them to bytes.
 	c.Resource.Limits.Memory *= 1024 * 1024
 	c.Resource.MinRequests.Memory *= 1024 * 1024
+	c.Resource.Requests.Memory *= 1024 * 1024
+	c.Resource.Requests.EphemeralStorage *= 1024 * 1024
 	c.StageRequests.Memory *= 1024 * 1024
 
 	rawsource, err := ioutil.ReadAll(c.Source)
@@ -89,6 +128,21 @@ func (c *execCommand) run(*kingpin.ParseContext) error {
 		return err
 	}
 
+	// verify the signature over the raw pipeline manifest before it
+	// is parsed, so that only a manifest signed off by a trusted
+	// key is ever compiled and executed.
+	if c.VerifyKey != "" {
+		if err := c.verify(rawsource); err != nil {
+			entry := logrus.WithError(err)
+			var sigErr *compiler.SignatureError
+			if errors.As(err, &sigErr) {
+				entry = entry.WithField("kid", sigErr.KeyID)
+			}
+			entry.Error("pipeline signature verification failed")
+			os.Exit(3)
+		}
+	}
+
 	kubeconfig := c.KubeConfig
 	if kubeconfig == "" {
 		dir, _ := os.UserHomeDir()
@@ -114,6 +168,36 @@ func (c *execCommand) run(*kingpin.ParseContext) error {
 		}
 	}
 
+	// load shared secret and registry plugin settings from the
+	// config file, if provided. explicit flags always win.
+	if c.ConfigFile != "" {
+		conf, err := loadSecretConfig(c.ConfigFile)
+		if err != nil {
+			return err
+		}
+		c.applyConfig(conf)
+	}
+
+	// combine the static secrets with the external secret plugin,
+	// if an endpoint is configured, so pipelines can resolve
+	// secrets from a remote plugin server instead of only inline values.
+	secrets := secret.StaticVars(c.Secrets)
+	if c.SecretEndpoint != "" {
+		secrets = secret.Combine(
+			secrets,
+			secret.External(c.SecretEndpoint, c.SecretToken, c.SecretSkipVerify),
+		)
+	}
+
+	// combine the (empty) static registry list with the external
+	// registry credential plugin, if an endpoint is configured.
+	registries := registry.Combine()
+	if c.RegistryEndpoint != "" {
+		registries = registry.Combine(
+			registry.External(c.RegistryEndpoint, c.RegistryToken, c.RegistrySkipVerify),
+		)
+	}
+
 	// string substitution function ensures that string
 	// replacement variables are escaped and quoted if they
 	// contain newlines.
@@ -160,11 +244,12 @@ func (c *execCommand) run(*kingpin.ParseContext) error {
 		Tmate:      c.Tmate,
 		Privileged: append(c.Privileged, compiler.Privileged...),
 		Volumes:    c.Volumes,
-		Secret:     secret.StaticVars(c.Secrets),
-		Registry:   registry.Combine(),
+		Secret:     secrets,
+		Registry:   registries,
 		Resources: compiler.Resources{
 			Limits:      c.Resource.Limits,
 			MinRequests: c.Resource.MinRequests,
+			Requests:    c.Resource.Requests,
 		},
 		StageRequests: c.StageRequests,
 		Namespace:     c.Namespace,
@@ -179,10 +264,25 @@ func (c *execCommand) run(*kingpin.ParseContext) error {
 		Repo:     c.Repo,
 		Stage:    c.Stage,
 		System:   c.System,
-		Secret:   secret.StaticVars(c.Secrets),
+		Secret:   secrets,
 	}
 	spec := comp.Compile(nocontext, args).(*engine.Spec)
 
+	// apply operator-supplied scheduling defaults (node selector,
+	// tolerations, runtime/priority class, service account) on top of
+	// any scheduling the pipeline declared; see engine.ApplySchedule.
+	if schedule := c.schedule(); schedule != nil {
+		engine.ApplySchedule(spec, schedule)
+	}
+
+	// enforce the requested quality-of-service class across every
+	// step's container resources.
+	if c.QoSClass != "" {
+		if err := compiler.ApplyQoS(spec, compiler.QoSClass(c.QoSClass)); err != nil {
+			return err
+		}
+	}
+
 	// include only steps that are in the include list,
 	// if the list in non-empty.
 	if len(c.Include) > 0 {
@@ -266,20 +366,39 @@ func (c *execCommand) run(*kingpin.ParseContext) error {
 		),
 	)
 
-	// change to out-of-cluster for local testing
-	kubeClient, err := kube.NewFromConfig(&c.KubeClient, kubeconfig)
+	// when running in job mode, the engine wraps each step's pod in a
+	// batchv1.Job instead of submitting it directly.
+	var jobOpts *engine.JobOptions
+	if c.Engine.Mode == "job" {
+		jobOpts = &engine.JobOptions{
+			BackoffLimit:            c.Engine.JobBackoffLimit,
+			TTLSecondsAfterFinished: c.Engine.JobTTLSecondsAfterFinished,
+			ActiveDeadlineSeconds:   c.Engine.JobActiveDeadlineSeconds,
+		}
+	}
+
+	// build the engine behind a small interface so the backend, kube
+	// or docker, can be selected at runtime. the kube client is only
+	// constructed when the kube backend is actually used. the docker
+	// backend additionally requires the binary to be built with the
+	// `docker` build tag; see engine/backend/docker.go.
+	eng, err := backend.New(backend.Options{
+		Kind:                  backend.Kind(c.Backend),
+		Kubeconfig:            kubeconfig,
+		KubeClient:            c.KubeClient,
+		ContainerStartTimeout: time.Duration(c.Engine.ContainerStartTimeout) * time.Second,
+		JobOptions:            jobOpts,
+		DockerHost:            c.DockerHost,
+	})
 	if err != nil {
 		return err
 	}
 
-	engine := engine.New(kubeClient,
-		time.Duration(c.Engine.ContainerStartTimeout)*time.Second)
-
 	err = runtime.NewExecer(
 		pipeline.NopReporter(),
 		console.New(c.Pretty),
 		pipeline.NopUploader(),
-		engine,
+		eng,
 		c.Procs,
 	).Exec(ctx, spec, state)
 
@@ -302,6 +421,111 @@ func (c *execCommand) run(*kingpin.ParseContext) error {
 	return nil
 }
 
+// schedule builds the operator-level pod scheduling overrides from
+// the exec flags, or returns nil if none were set.
+func (c *execCommand) schedule() *engine.PodScheduling {
+	if len(c.NodeSelector) == 0 &&
+		len(c.Tolerations) == 0 &&
+		len(c.TolerateTaints) == 0 &&
+		len(c.NodeAffinity) == 0 &&
+		len(c.TopologySpread) == 0 &&
+		c.RuntimeClassName == "" &&
+		c.PriorityClassName == "" &&
+		c.ServiceAccountName == "" {
+		return nil
+	}
+
+	s := &engine.PodScheduling{
+		NodeSelector:       c.NodeSelector,
+		RuntimeClassName:   c.RuntimeClassName,
+		PriorityClassName:  c.PriorityClassName,
+		ServiceAccountName: c.ServiceAccountName,
+	}
+
+	for _, t := range c.Tolerations {
+		// key=value:effect
+		kv := strings.SplitN(t, "=", 2)
+		toleration := engine.Toleration{Operator: "Equal"}
+		toleration.Key = kv[0]
+		if len(kv) == 2 {
+			rest := strings.SplitN(kv[1], ":", 2)
+			toleration.Value = rest[0]
+			if len(rest) == 2 {
+				toleration.Effect = rest[1]
+			}
+		}
+		s.Tolerations = append(s.Tolerations, toleration)
+	}
+
+	for _, t := range c.TolerateTaints {
+		// key:effect, tolerates any value for the taint
+		kv := strings.SplitN(t, ":", 2)
+		toleration := engine.Toleration{Operator: "Exists", Key: kv[0]}
+		if len(kv) == 2 {
+			toleration.Effect = kv[1]
+		}
+		s.Tolerations = append(s.Tolerations, toleration)
+	}
+
+	if len(c.NodeAffinity) > 0 {
+		s.Affinity = &engine.Affinity{
+			NodeAffinity: &engine.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: c.NodeAffinity,
+			},
+		}
+	}
+
+	for _, t := range c.TopologySpread {
+		// topologykey:maxskew:whenunsatisfiable
+		parts := strings.SplitN(t, ":", 3)
+		constraint := engine.TopologySpreadConstraint{TopologyKey: parts[0]}
+		if len(parts) > 1 {
+			if maxSkew, err := strconv.Atoi(parts[1]); err == nil {
+				constraint.MaxSkew = int32(maxSkew)
+			}
+		}
+		if len(parts) > 2 {
+			constraint.WhenUnsatisfiable = parts[2]
+		}
+		s.TopologySpreadConstraints = append(s.TopologySpreadConstraints, constraint)
+	}
+
+	return s
+}
+
+// applyConfig merges the secret and registry plugin settings loaded
+// from the config file into c, without overriding any flag the
+// operator explicitly set on the command line.
+func (c *execCommand) applyConfig(conf *secretConfig) {
+	if c.SecretEndpoint == "" {
+		c.SecretEndpoint = conf.Secret.Endpoint
+	}
+	if c.SecretToken == "" {
+		c.SecretToken = conf.Secret.Token
+	}
+	if !c.secretSkipVerifySet {
+		c.SecretSkipVerify = conf.Secret.SkipVerify
+	}
+	if c.RegistryEndpoint == "" {
+		c.RegistryEndpoint = conf.Registry.Endpoint
+	}
+	if c.RegistryToken == "" {
+		c.RegistryToken = conf.Registry.Token
+	}
+	if !c.registrySkipVerifySet {
+		c.RegistrySkipVerify = conf.Registry.SkipVerify
+	}
+}
+
+// verify validates the signature over raw using the configured
+// verify key and signature file.
+//
+// This wires verification into the exec subcommand only; this tree
+// has no daemon/server command to mirror it into.
+func (c *execCommand) verify(raw []byte) error {
+	return compiler.VerifyManifestFile(raw, c.VerifyKey, c.Source.Name(), c.VerifySignature)
+}
+
 func dump(v interface{}) {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
@@ -349,6 +573,13 @@ func registerExec(app *kingpin.Application) {
 	cmd.Flag("kubeconfig", "path to the kubernetes config file").
 		StringVar(&c.KubeConfig)
 
+	cmd.Flag("engine", "execution backend, kube or docker (docker requires a binary built with -tags docker)").
+		Default("kube").
+		EnumVar(&c.Backend, "kube", "docker")
+
+	cmd.Flag("docker-host", "docker daemon host, used when --engine=docker").
+		StringVar(&c.DockerHost)
+
 	cmd.Flag("limit-memory", "memory limit in MiB for containers").
 		Int64Var(&c.Resource.Limits.Memory)
 
@@ -374,9 +605,77 @@ func registerExec(app *kingpin.Application) {
 		Default("1").
 		Int64Var(&c.Resource.MinRequests.CPU)
 
+	cmd.Flag("request-container-memory", "memory request in MiB for containers").
+		Int64Var(&c.Resource.Requests.Memory)
+
+	cmd.Flag("request-container-cpu", "cpu request in millicores for containers").
+		Int64Var(&c.Resource.Requests.CPU)
+
+	cmd.Flag("request-container-gpu", "gpu request for containers").
+		Int64Var(&c.Resource.Requests.GPU)
+
+	cmd.Flag("request-container-ephemeral-storage", "ephemeral storage request in MiB for containers").
+		Int64Var(&c.Resource.Requests.EphemeralStorage)
+
+	cmd.Flag("qos-class", "pod quality of service class, guaranteed, burstable or besteffort").
+		EnumVar(&c.QoSClass, "guaranteed", "burstable", "besteffort")
+
 	cmd.Flag("policy", "path to the pipeline policy file").
 		StringVar(&c.Policy)
 
+	cmd.Flag("config", "path to a config file with secret and registry plugin settings").
+		StringVar(&c.ConfigFile)
+
+	cmd.Flag("verify-key", "path to the public key or hmac secret used to verify the pipeline signature").
+		StringVar(&c.VerifyKey)
+
+	cmd.Flag("verify-signature", "path to the pipeline signature file, defaults to <source>.sig").
+		StringVar(&c.VerifySignature)
+
+	cmd.Flag("node-selector", "node selector labels the pod must match").
+		StringMapVar(&c.NodeSelector)
+
+	cmd.Flag("toleration", "toleration in key=value:effect form, repeatable").
+		StringsVar(&c.Tolerations)
+
+	cmd.Flag("tolerate-taint", "tolerate any value for a taint, in key:effect form, repeatable").
+		StringsVar(&c.TolerateTaints)
+
+	cmd.Flag("node-affinity", "required node affinity labels the pod must match").
+		StringMapVar(&c.NodeAffinity)
+
+	cmd.Flag("topology-spread", "topology spread constraint in topologykey:maxskew:whenunsatisfiable form, repeatable").
+		StringsVar(&c.TopologySpread)
+
+	cmd.Flag("runtime-class", "kubernetes runtime class for stage pods").
+		StringVar(&c.RuntimeClassName)
+
+	cmd.Flag("priority-class", "kubernetes priority class for stage pods").
+		StringVar(&c.PriorityClassName)
+
+	cmd.Flag("service-account", "kubernetes service account for stage pods").
+		StringVar(&c.ServiceAccountName)
+
+	cmd.Flag("secret-endpoint", "external secret plugin endpoint").
+		StringVar(&c.SecretEndpoint)
+
+	cmd.Flag("secret-token", "external secret plugin token").
+		StringVar(&c.SecretToken)
+
+	cmd.Flag("secret-skip-verify", "skip tls verification for the secret plugin").
+		IsSetByUser(&c.secretSkipVerifySet).
+		BoolVar(&c.SecretSkipVerify)
+
+	cmd.Flag("registry-endpoint", "external registry credential plugin endpoint").
+		StringVar(&c.RegistryEndpoint)
+
+	cmd.Flag("registry-token", "external registry credential plugin token").
+		StringVar(&c.RegistryToken)
+
+	cmd.Flag("registry-skip-verify", "skip tls verification for the registry plugin").
+		IsSetByUser(&c.registrySkipVerifySet).
+		BoolVar(&c.RegistrySkipVerify)
+
 	cmd.Flag("namespace", "default kubernetes namespace").
 		Default("default").
 		StringVar(&c.Namespace)
@@ -422,6 +721,20 @@ func registerExec(app *kingpin.Application) {
 		Default("480").
 		IntVar(&c.Engine.ContainerStartTimeout)
 
+	cmd.Flag("engine-mode", "stage execution mode, pod or job").
+		Default("pod").
+		EnumVar(&c.Engine.Mode, "pod", "job")
+
+	cmd.Flag("engine-job-backoff-limit", "number of job retries before the stage is considered failed").
+		Default("0").
+		Int32Var(&c.Engine.JobBackoffLimit)
+
+	cmd.Flag("engine-job-ttl-seconds-after-finished", "seconds to keep a finished job before it is garbage collected").
+		Int32Var(&c.Engine.JobTTLSecondsAfterFinished)
+
+	cmd.Flag("engine-job-active-deadline-seconds", "seconds a job may run before it is terminated").
+		Int64Var(&c.Engine.JobActiveDeadlineSeconds)
+
 	cmd.Flag("kube-client-qps", "k8s client throttle control: maximum queries per second").
 		Float32Var(&c.KubeClient.QPS)
 
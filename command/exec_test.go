@@ -0,0 +1,92 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package command
+
+import "testing"
+
+func TestApplyConfig(t *testing.T) {
+	conf := &secretConfig{}
+	conf.Secret.Endpoint = "https://secret.example.com"
+	conf.Secret.Token = "secret-token"
+	conf.Secret.SkipVerify = true
+	conf.Registry.Endpoint = "https://registry.example.com"
+	conf.Registry.Token = "registry-token"
+	conf.Registry.SkipVerify = true
+
+	t.Run("fills in unset fields", func(t *testing.T) {
+		c := &execCommand{}
+		c.applyConfig(conf)
+
+		if c.SecretEndpoint != conf.Secret.Endpoint {
+			t.Errorf("want secret endpoint %q, got %q", conf.Secret.Endpoint, c.SecretEndpoint)
+		}
+		if c.SecretToken != conf.Secret.Token {
+			t.Errorf("want secret token %q, got %q", conf.Secret.Token, c.SecretToken)
+		}
+		if !c.SecretSkipVerify {
+			t.Error("want secret skip verify true")
+		}
+		if c.RegistryEndpoint != conf.Registry.Endpoint {
+			t.Errorf("want registry endpoint %q, got %q", conf.Registry.Endpoint, c.RegistryEndpoint)
+		}
+		if !c.RegistrySkipVerify {
+			t.Error("want registry skip verify true")
+		}
+	})
+
+	t.Run("does not override flags the operator explicitly set", func(t *testing.T) {
+		c := &execCommand{
+			SecretEndpoint:        "https://flag.example.com",
+			SecretSkipVerify:      false,
+			secretSkipVerifySet:   true,
+			RegistrySkipVerify:    false,
+			registrySkipVerifySet: true,
+		}
+		c.applyConfig(conf)
+
+		if c.SecretEndpoint != "https://flag.example.com" {
+			t.Errorf("want flag-supplied secret endpoint to win, got %q", c.SecretEndpoint)
+		}
+		if c.SecretSkipVerify {
+			t.Error("want explicit --secret-skip-verify=false to win over config file true")
+		}
+		if c.RegistrySkipVerify {
+			t.Error("want explicit --registry-skip-verify=false to win over config file true")
+		}
+	})
+}
+
+func TestExecCommandSchedule(t *testing.T) {
+	t.Run("no flags set returns nil", func(t *testing.T) {
+		c := &execCommand{}
+		if s := c.schedule(); s != nil {
+			t.Errorf("want nil schedule, got %+v", s)
+		}
+	})
+
+	t.Run("node affinity and topology spread", func(t *testing.T) {
+		c := &execCommand{
+			NodeAffinity:   map[string]string{"disktype": "ssd"},
+			TopologySpread: []string{"zone:1:DoNotSchedule"},
+		}
+		s := c.schedule()
+		if s == nil {
+			t.Fatal("want non-nil schedule")
+		}
+		if s.Affinity == nil || s.Affinity.NodeAffinity == nil {
+			t.Fatal("want node affinity to be set")
+		}
+		if got := s.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution["disktype"]; got != "ssd" {
+			t.Errorf("want required node affinity disktype=ssd, got %q", got)
+		}
+		if len(s.TopologySpreadConstraints) != 1 {
+			t.Fatalf("want 1 topology spread constraint, got %d", len(s.TopologySpreadConstraints))
+		}
+		c2 := s.TopologySpreadConstraints[0]
+		if c2.TopologyKey != "zone" || c2.MaxSkew != 1 || c2.WhenUnsatisfiable != "DoNotSchedule" {
+			t.Errorf("unexpected topology spread constraint: %+v", c2)
+		}
+	})
+}
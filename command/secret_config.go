@@ -0,0 +1,42 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package command
+
+import (
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// secretConfig defines the external secret and registry credential
+// plugin settings that can be loaded from a config file, so an
+// operator does not have to repeat them as flags on every `exec`
+// invocation.
+type secretConfig struct {
+	Secret struct {
+		Endpoint   string `yaml:"endpoint"`
+		Token      string `yaml:"token"`
+		SkipVerify bool   `yaml:"skip_verify"`
+	} `yaml:"secret"`
+	Registry struct {
+		Endpoint   string `yaml:"endpoint"`
+		Token      string `yaml:"token"`
+		SkipVerify bool   `yaml:"skip_verify"`
+	} `yaml:"registry"`
+}
+
+// loadSecretConfig reads and parses the secret and registry plugin
+// config file at path.
+func loadSecretConfig(path string) (*secretConfig, error) {
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	conf := new(secretConfig)
+	if err := yaml.Unmarshal(out, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package command
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type signCommand struct {
+	Source *os.File
+	Key    *os.File
+	Output string
+}
+
+func (c *signCommand) run(*kingpin.ParseContext) error {
+	raw, err := ioutil.ReadAll(c.Source)
+	if err != nil {
+		return err
+	}
+	keyraw, err := ioutil.ReadAll(c.Key)
+	if err != nil {
+		return err
+	}
+
+	signer, err := newSigner(keyraw)
+	if err != nil {
+		return err
+	}
+
+	object, err := signer.Sign(raw)
+	if err != nil {
+		return err
+	}
+	out, err := object.CompactSerialize()
+	if err != nil {
+		return err
+	}
+
+	output := c.Output
+	if output == "" {
+		output = c.Source.Name() + ".sig"
+	}
+	return ioutil.WriteFile(output, []byte(out), 0644)
+}
+
+// newSigner builds a jose.Signer from a PEM-encoded private key, or
+// from a raw HMAC shared secret if raw is not a valid PEM block.
+func newSigner(raw []byte) (jose.Signer, error) {
+	alg := jose.HS256
+	var key interface{} = raw
+
+	if block, _ := pem.Decode(raw); block != nil {
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch k := priv.(type) {
+		case *rsa.PrivateKey:
+			alg = jose.RS256
+		case *ecdsa.PrivateKey:
+			switch k.Curve {
+			case elliptic.P256():
+				alg = jose.ES256
+			case elliptic.P384():
+				alg = jose.ES384
+			case elliptic.P521():
+				alg = jose.ES512
+			default:
+				return nil, fmt.Errorf("sign: unsupported ecdsa curve %s", k.Curve.Params().Name)
+			}
+		case ed25519.PrivateKey:
+			alg = jose.EdDSA
+		default:
+			return nil, fmt.Errorf("sign: unsupported key type %T", priv)
+		}
+		key = priv
+	}
+
+	return jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, nil)
+}
+
+func registerSign(app *kingpin.Application) {
+	c := new(signCommand)
+
+	cmd := app.Command("sign", "signs a pipeline manifest").
+		Action(c.run)
+
+	cmd.Arg("source", "source file location").
+		Default(".drone.yml").
+		FileVar(&c.Source)
+
+	cmd.Flag("key", "path to the signing key").
+		Required().
+		FileVar(&c.Key)
+
+	cmd.Flag("output", "path to write the signature file").
+		StringVar(&c.Output)
+}
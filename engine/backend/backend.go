@@ -0,0 +1,71 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+// Package backend selects and constructs the pipeline execution
+// engine, so that `drone-runner-kube exec` can run pipelines
+// against a Kubernetes cluster or, for local development, against
+// a Docker daemon. The docker backend is only registered when the
+// binary is built with the `docker` build tag; see docker.go.
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/drone-runners/drone-runner-kube/engine"
+	"github.com/drone-runners/drone-runner-kube/internal/kube"
+	"github.com/drone/runner-go/pipeline/runtime"
+)
+
+// Kind identifies an execution backend.
+type Kind string
+
+// Supported backend kinds.
+const (
+	Kube   Kind = "kube"
+	Docker Kind = "docker"
+)
+
+// Options configures backend construction.
+type Options struct {
+	Kind Kind
+
+	// Kube backend options. The kube client is constructed lazily,
+	// only once a kube backend is actually requested, so `exec
+	// --engine=docker` can run without a kubeconfig.
+	Kubeconfig            string
+	KubeClient            kube.ClientConfig
+	ContainerStartTimeout time.Duration
+	JobOptions            *engine.JobOptions
+
+	// Docker backend options.
+	DockerHost string
+}
+
+// DockerEngine constructs the docker execution backend. It is a
+// package variable, rather than a direct dependency, so that
+// drone-runner-kube does not have to vendor the docker engine
+// implementation to support the kube-only build. It is assigned by
+// docker.go's init function, which only compiles in with the
+// `docker` build tag.
+var DockerEngine func(host string) (runtime.Engine, error)
+
+// New builds the pipeline engine backend configured by opts.
+func New(opts Options) (runtime.Engine, error) {
+	switch opts.Kind {
+	case Docker:
+		if DockerEngine == nil {
+			return nil, fmt.Errorf("backend: docker engine is not registered, rebuild with -tags docker to enable --engine=docker")
+		}
+		return DockerEngine(opts.DockerHost)
+	case Kube, "":
+		kubeClient, err := kube.NewFromConfig(&opts.KubeClient, opts.Kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		return engine.New(kubeClient, opts.ContainerStartTimeout, opts.JobOptions), nil
+	default:
+		return nil, fmt.Errorf("backend: unknown engine %q", opts.Kind)
+	}
+}
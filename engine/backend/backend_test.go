@@ -0,0 +1,25 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package backend
+
+import "testing"
+
+func TestNewDockerNotRegistered(t *testing.T) {
+	prev := DockerEngine
+	DockerEngine = nil
+	defer func() { DockerEngine = prev }()
+
+	_, err := New(Options{Kind: Docker})
+	if err == nil {
+		t.Fatal("want error when no docker engine constructor is registered")
+	}
+}
+
+func TestNewUnknownKind(t *testing.T) {
+	_, err := New(Options{Kind: "bogus"})
+	if err == nil {
+		t.Fatal("want error for an unknown backend kind")
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+//go:build docker
+// +build docker
+
+package backend
+
+import (
+	dockerengine "github.com/drone-runners/drone-runner-docker/engine"
+	"github.com/drone/runner-go/pipeline/runtime"
+)
+
+func init() {
+	DockerEngine = newDockerEngine
+}
+
+// newDockerEngine constructs the docker execution engine, pointed
+// at host, or the DOCKER_HOST environment variable when host is
+// empty.
+func newDockerEngine(host string) (runtime.Engine, error) {
+	return dockerengine.NewEnv(host)
+}
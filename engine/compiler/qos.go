@@ -0,0 +1,63 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/drone-runners/drone-runner-kube/engine"
+)
+
+// QoSClass selects the Kubernetes quality-of-service class that
+// stage containers are scheduled with.
+type QoSClass string
+
+// QoS classes supported by EnforceQoS.
+const (
+	QoSGuaranteed QoSClass = "guaranteed"
+	QoSBurstable  QoSClass = "burstable"
+	QoSBestEffort QoSClass = "besteffort"
+)
+
+// ApplyQoS enforces class across every step in spec, returning an
+// error if the pipeline cannot satisfy the requested class.
+func ApplyQoS(spec *engine.Spec, class QoSClass) error {
+	resources := make([]*engine.Resources, 0, len(spec.Steps))
+	for _, step := range spec.Steps {
+		resources = append(resources, &step.Resources)
+	}
+	return EnforceQoS(resources, class)
+}
+
+// EnforceQoS applies class to each of resources, returning an error
+// if the pipeline cannot satisfy the requested class.
+//
+// guaranteed copies limits into requests, and rejects the pipeline
+// if any container is missing a cpu or memory limit. burstable
+// requires every container to already have a cpu or memory request.
+// besteffort strips both limits and requests.
+func EnforceQoS(resources []*engine.Resources, class QoSClass) error {
+	switch class {
+	case QoSGuaranteed:
+		for _, r := range resources {
+			if r.Limits.CPU == 0 || r.Limits.Memory == 0 {
+				return fmt.Errorf("compiler: qos class guaranteed requires a cpu and memory limit on every container")
+			}
+			r.Requests = r.Limits
+		}
+	case QoSBurstable:
+		for _, r := range resources {
+			if r.Requests.CPU == 0 && r.Requests.Memory == 0 {
+				return fmt.Errorf("compiler: qos class burstable requires at least one resource request")
+			}
+		}
+	case QoSBestEffort:
+		for _, r := range resources {
+			r.Limits = engine.ResourceObject{}
+			r.Requests = engine.ResourceObject{}
+		}
+	}
+	return nil
+}
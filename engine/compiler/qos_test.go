@@ -0,0 +1,73 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/drone-runners/drone-runner-kube/engine"
+)
+
+func TestEnforceQoSGuaranteed(t *testing.T) {
+	resources := []*engine.Resources{
+		{Limits: engine.ResourceObject{CPU: 100, Memory: 1024}},
+	}
+	if err := EnforceQoS(resources, QoSGuaranteed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resources[0].Requests != resources[0].Limits {
+		t.Errorf("want requests to mirror limits, got %+v", resources[0].Requests)
+	}
+}
+
+func TestEnforceQoSGuaranteedMissingLimit(t *testing.T) {
+	resources := []*engine.Resources{
+		{Limits: engine.ResourceObject{CPU: 100}},
+	}
+	if err := EnforceQoS(resources, QoSGuaranteed); err == nil {
+		t.Fatal("want error when a container is missing a memory limit")
+	}
+}
+
+func TestEnforceQoSBurstableRequiresRequest(t *testing.T) {
+	resources := []*engine.Resources{
+		{},
+	}
+	if err := EnforceQoS(resources, QoSBurstable); err == nil {
+		t.Fatal("want error when no resource request is set")
+	}
+}
+
+func TestEnforceQoSBestEffortStripsResources(t *testing.T) {
+	resources := []*engine.Resources{
+		{
+			Limits:   engine.ResourceObject{CPU: 100, Memory: 1024},
+			Requests: engine.ResourceObject{CPU: 50, Memory: 512},
+		},
+	}
+	if err := EnforceQoS(resources, QoSBestEffort); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resources[0].Limits != (engine.ResourceObject{}) || resources[0].Requests != (engine.ResourceObject{}) {
+		t.Errorf("want limits and requests stripped, got %+v", resources[0])
+	}
+}
+
+func TestApplyQoSAppliesToEverySpecStep(t *testing.T) {
+	spec := &engine.Spec{
+		Steps: []*engine.Step{
+			{Resources: engine.Resources{Limits: engine.ResourceObject{CPU: 100, Memory: 1024}}},
+			{Resources: engine.Resources{Limits: engine.ResourceObject{CPU: 200, Memory: 2048}}},
+		},
+	}
+	if err := ApplyQoS(spec, QoSGuaranteed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, step := range spec.Steps {
+		if step.Resources.Requests != step.Resources.Limits {
+			t.Errorf("step %d: want requests to mirror limits, got %+v", i, step.Resources.Requests)
+		}
+	}
+}
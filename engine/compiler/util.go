@@ -97,11 +97,87 @@ func convertSecretEnv(src map[string]*manifest.Variable) []*engine.SecretVar {
 func convertResources(src resource.Resources) engine.Resources {
 	return engine.Resources{
 		Limits: engine.ResourceObject{
-			CPU:    src.Limits.CPU,
-			Memory: int64(src.Limits.Memory),
-			GPU:    src.Limits.GPU,
+			CPU:              src.Limits.CPU,
+			Memory:           int64(src.Limits.Memory),
+			GPU:              src.Limits.GPU,
+			EphemeralStorage: int64(src.Limits.EphemeralStorage),
 		},
+		Requests: engine.ResourceObject{
+			CPU:              src.Requests.CPU,
+			Memory:           int64(src.Requests.Memory),
+			GPU:              src.Requests.GPU,
+			EphemeralStorage: int64(src.Requests.EphemeralStorage),
+		},
+	}
+}
+
+// helper function converts the pipeline scheduling fields (node
+// selector, tolerations, affinity, topology spread constraints,
+// runtime class, priority class and service account) to the
+// scheduling structure used by the engine.
+func convertSchedule(src *resource.Pipeline) *engine.PodScheduling {
+	dst := &engine.PodScheduling{
+		NodeSelector:              src.NodeSelector,
+		Tolerations:               convertTolerations(src.Tolerations),
+		Affinity:                  convertAffinity(src.Affinity),
+		TopologySpreadConstraints: convertTopologySpread(src.TopologySpreadConstraints),
+		RuntimeClassName:          src.RuntimeClassName,
+		PriorityClassName:         src.PriorityClassName,
+		ServiceAccountName:        src.ServiceAccountName,
 	}
+	return dst
+}
+
+func convertTolerations(src []resource.Toleration) []engine.Toleration {
+	dst := make([]engine.Toleration, len(src))
+	for i, t := range src {
+		dst[i] = engine.Toleration{
+			Key:      t.Key,
+			Operator: t.Operator,
+			Value:    t.Value,
+			Effect:   t.Effect,
+		}
+	}
+	return dst
+}
+
+func convertTopologySpread(src []resource.TopologySpreadConstraint) []engine.TopologySpreadConstraint {
+	dst := make([]engine.TopologySpreadConstraint, len(src))
+	for i, t := range src {
+		dst[i] = engine.TopologySpreadConstraint{
+			MaxSkew:           t.MaxSkew,
+			TopologyKey:       t.TopologyKey,
+			WhenUnsatisfiable: t.WhenUnsatisfiable,
+			LabelSelector:     t.LabelSelector,
+		}
+	}
+	return dst
+}
+
+func convertAffinity(src *resource.Affinity) *engine.Affinity {
+	if src == nil {
+		return nil
+	}
+	dst := &engine.Affinity{}
+	if src.NodeAffinity != nil {
+		dst.NodeAffinity = &engine.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution:  src.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+			PreferredDuringSchedulingIgnoredDuringExecution: src.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		}
+	}
+	if src.PodAffinity != nil {
+		dst.PodAffinity = &engine.PodAffinity{
+			TopologyKey:   src.PodAffinity.TopologyKey,
+			LabelSelector: src.PodAffinity.LabelSelector,
+		}
+	}
+	if src.PodAntiAffinity != nil {
+		dst.PodAntiAffinity = &engine.PodAffinity{
+			TopologyKey:   src.PodAntiAffinity.TopologyKey,
+			LabelSelector: src.PodAntiAffinity.LabelSelector,
+		}
+	}
+	return dst
 }
 
 // helper function modifies the pipeline dependency graph to
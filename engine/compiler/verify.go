@@ -0,0 +1,113 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// VerifyOptions configures signature verification for a pipeline
+// manifest.
+type VerifyOptions struct {
+	// Key is the PEM-encoded public key, or HMAC shared secret,
+	// used to verify the signature.
+	Key []byte
+
+	// KeyID, if set, must match the kid header of the signature.
+	KeyID string
+
+	// Signature is the raw JWS compact-serialized signature.
+	Signature []byte
+}
+
+// SignatureError is returned when a pipeline manifest fails
+// signature verification.
+type SignatureError struct {
+	KeyID string
+	Err   error
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("compiler: signature verification failed, kid=%q: %s", e.KeyID, e.Err)
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyManifest verifies the JWS signature over raw, the unparsed
+// pipeline manifest, using the key and kid configured in opts. It
+// is the caller's responsibility to read raw and opts.Signature
+// before manifest.ParseString is invoked, so that only a signed
+// manifest is ever compiled.
+func VerifyManifest(raw []byte, opts VerifyOptions) error {
+	object, err := jose.ParseSigned(string(opts.Signature))
+	if err != nil {
+		return &SignatureError{KeyID: opts.KeyID, Err: err}
+	}
+
+	var kid string
+	if len(object.Signatures) > 0 {
+		kid = object.Signatures[0].Header.KeyID
+	}
+	if opts.KeyID != "" && kid != opts.KeyID {
+		return &SignatureError{KeyID: opts.KeyID, Err: fmt.Errorf("unexpected signer")}
+	}
+
+	key := parseVerifyKey(opts.Key)
+	payload, err := object.Verify(key)
+	if err != nil {
+		return &SignatureError{KeyID: kid, Err: err}
+	}
+	if string(payload) != string(raw) {
+		return &SignatureError{KeyID: kid, Err: fmt.Errorf("payload mismatch")}
+	}
+	return nil
+}
+
+// VerifyManifestFile reads the verify key at keyPath and the
+// signature at sigPath, or sourcePath+".sig" if sigPath is empty,
+// and verifies raw against them. It is a thin wrapper around
+// VerifyManifest so that any command which reads a pipeline
+// manifest from disk can perform signature verification the same
+// way, without duplicating the key/signature file handling.
+func VerifyManifestFile(raw []byte, keyPath, sourcePath, sigPath string) error {
+	key, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+
+	if sigPath == "" {
+		sigPath = sourcePath + ".sig"
+	}
+	sig, err := ioutil.ReadFile(sigPath)
+	if err != nil {
+		return err
+	}
+
+	return VerifyManifest(raw, VerifyOptions{
+		Key:       key,
+		Signature: sig,
+	})
+}
+
+// parseVerifyKey decodes raw as a PEM-encoded public key. If it is
+// not a valid PEM block, raw is treated as an HMAC shared secret.
+func parseVerifyKey(raw []byte) interface{} {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return raw
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return raw
+	}
+	return pub
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestVerifyManifestFile(t *testing.T) {
+	raw := []byte("kind: pipeline\n")
+	key := []byte("super-secret-hmac-key")
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: key}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := signer.Sign(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := object.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key")
+	sourcePath := filepath.Join(dir, ".drone.yml")
+	if err := ioutil.WriteFile(keyPath, key, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(sourcePath+".sig", []byte(sig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyManifestFile(raw, keyPath, sourcePath, ""); err != nil {
+		t.Fatalf("expected verification to succeed: %v", err)
+	}
+
+	if err := VerifyManifestFile([]byte("tampered"), keyPath, sourcePath, ""); err == nil {
+		t.Fatal("expected verification to fail for a tampered payload")
+	}
+}
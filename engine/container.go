@@ -0,0 +1,53 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// toContainers builds the pod's container list for step, so that
+// the resource requests and limits computed by the compiler (and
+// enforced by compiler.ApplyQoS) actually land on the container
+// Kubernetes schedules, rather than being dropped on the floor.
+func toContainers(step *Step) []corev1.Container {
+	return []corev1.Container{
+		{
+			Name:      step.Name,
+			Resources: toResourceRequirements(step.Resources),
+		},
+	}
+}
+
+// toResourceRequirements converts the engine's resource limits and
+// requests to the corev1 equivalent understood by the Kubernetes
+// API.
+func toResourceRequirements(r Resources) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Limits:   toResourceList(r.Limits),
+		Requests: toResourceList(r.Requests),
+	}
+}
+
+// toResourceList converts a single set of CPU, memory, GPU and
+// ephemeral storage quantities to a corev1.ResourceList, omitting
+// any quantity that is zero.
+func toResourceList(r ResourceObject) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	if r.CPU > 0 {
+		list[corev1.ResourceCPU] = *apiresource.NewMilliQuantity(r.CPU, apiresource.DecimalSI)
+	}
+	if r.Memory > 0 {
+		list[corev1.ResourceMemory] = *apiresource.NewQuantity(r.Memory, apiresource.BinarySI)
+	}
+	if r.GPU > 0 {
+		list[corev1.ResourceName("nvidia.com/gpu")] = *apiresource.NewQuantity(r.GPU, apiresource.DecimalSI)
+	}
+	if r.EphemeralStorage > 0 {
+		list[corev1.ResourceEphemeralStorage] = *apiresource.NewQuantity(r.EphemeralStorage, apiresource.BinarySI)
+	}
+	return list
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestToContainers(t *testing.T) {
+	step := &Step{
+		Name: "build",
+		Resources: Resources{
+			Limits:   ResourceObject{CPU: 1000, Memory: 1024},
+			Requests: ResourceObject{CPU: 500, Memory: 512},
+		},
+	}
+
+	containers := toContainers(step)
+	if len(containers) != 1 {
+		t.Fatalf("want 1 container, got %d", len(containers))
+	}
+	container := containers[0]
+	if container.Name != "build" {
+		t.Errorf("want container name %q, got %q", "build", container.Name)
+	}
+
+	limits := container.Resources.Limits[corev1.ResourceCPU]
+	if limits.MilliValue() != 1000 {
+		t.Errorf("want cpu limit 1000m, got %v", limits.MilliValue())
+	}
+	requests := container.Resources.Requests[corev1.ResourceMemory]
+	if requests.Value() != 512 {
+		t.Errorf("want memory request 512, got %v", requests.Value())
+	}
+}
+
+func TestToResourceListOmitsZeroQuantities(t *testing.T) {
+	list := toResourceList(ResourceObject{CPU: 500})
+	if _, ok := list[corev1.ResourceCPU]; !ok {
+		t.Error("want cpu quantity present")
+	}
+	if _, ok := list[corev1.ResourceMemory]; ok {
+		t.Error("want memory quantity omitted when zero")
+	}
+	if _, ok := list[corev1.ResourceEphemeralStorage]; ok {
+		t.Error("want ephemeral storage quantity omitted when zero")
+	}
+}
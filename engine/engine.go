@@ -0,0 +1,100 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/drone/runner-go/pipeline/runtime"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Client creates the Kubernetes workloads the engine submits for a
+// pipeline step, and waits for them to reach a terminal state.
+type Client interface {
+	CreatePod(ctx context.Context, namespace string, pod *corev1.Pod) error
+	CreateJob(ctx context.Context, namespace string, job *batchv1.Job) error
+	WaitPod(ctx context.Context, namespace, name string) (*PodResult, error)
+	WaitJob(ctx context.Context, namespace, name string) (*PodResult, error)
+}
+
+// Engine executes a pipeline stage by submitting each step to
+// Kubernetes as a Pod, or as a Job when jobOpts is configured.
+type Engine struct {
+	client                Client
+	containerStartTimeout time.Duration
+	jobOpts               *JobOptions
+}
+
+// New returns a new Engine.
+func New(client Client, containerStartTimeout time.Duration, jobOpts *JobOptions) *Engine {
+	return &Engine{
+		client:                client,
+		containerStartTimeout: containerStartTimeout,
+		jobOpts:               jobOpts,
+	}
+}
+
+// Setup prepares the pipeline environment. There is nothing to
+// provision up front: the namespace is expected to already exist,
+// and each step's workload is created independently by Run.
+func (e *Engine) Setup(ctx context.Context, spec *Spec) error {
+	return nil
+}
+
+// Destroy cleans up the pipeline environment. There is nothing to
+// tear down here: Pods and Jobs are left in place for inspection,
+// and a configured JobOptions.TTLSecondsAfterFinished is what
+// garbage collects a Job-backed stage's workloads.
+func (e *Engine) Destroy(ctx context.Context, spec *Spec) error {
+	return nil
+}
+
+// Run submits step as a Kubernetes workload and watches it to
+// completion. When the engine is configured with JobOptions, step
+// is submitted as a batchv1.Job; otherwise it is submitted as a bare
+// Pod. This is the single point where the engine decides between
+// the two, so --engine-mode=job actually changes what gets created.
+func (e *Engine) Run(ctx context.Context, spec *Spec, step *Step, output io.Writer) (*runtime.State, error) {
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      step.Name,
+			Namespace: spec.Namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: toContainers(step),
+		},
+	}
+	applyPodScheduling(&template.Spec, spec.Schedule)
+
+	if e.jobOpts != nil {
+		job := toJob(step.Name, template, e.jobOpts)
+		if err := e.client.CreateJob(ctx, spec.Namespace, job); err != nil {
+			return nil, err
+		}
+		result, err := e.client.WaitJob(ctx, spec.Namespace, job.Name)
+		if err != nil {
+			return nil, err
+		}
+		return toState(result), nil
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: template.ObjectMeta,
+		Spec:       template.Spec,
+	}
+	if err := e.client.CreatePod(ctx, spec.Namespace, pod); err != nil {
+		return nil, err
+	}
+	result, err := e.client.WaitPod(ctx, spec.Namespace, pod.Name)
+	if err != nil {
+		return nil, err
+	}
+	return toState(result), nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type fakeClient struct {
+	pod *corev1.Pod
+	job *batchv1.Job
+
+	result *PodResult
+}
+
+func (f *fakeClient) CreatePod(ctx context.Context, namespace string, pod *corev1.Pod) error {
+	f.pod = pod
+	return nil
+}
+
+func (f *fakeClient) CreateJob(ctx context.Context, namespace string, job *batchv1.Job) error {
+	f.job = job
+	return nil
+}
+
+func (f *fakeClient) WaitPod(ctx context.Context, namespace, name string) (*PodResult, error) {
+	if f.result != nil {
+		return f.result, nil
+	}
+	return &PodResult{}, nil
+}
+
+func (f *fakeClient) WaitJob(ctx context.Context, namespace, name string) (*PodResult, error) {
+	if f.result != nil {
+		return f.result, nil
+	}
+	return &PodResult{}, nil
+}
+
+func TestEngineRunSubmitsPodByDefault(t *testing.T) {
+	client := &fakeClient{}
+	e := New(client, 0, nil)
+
+	spec := &Spec{Namespace: "default"}
+	step := &Step{Name: "build"}
+
+	if _, err := e.Run(context.Background(), spec, step, ioutil.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.pod == nil {
+		t.Fatal("want engine to create a pod when no JobOptions are configured")
+	}
+	if client.job != nil {
+		t.Fatal("want engine not to create a job when no JobOptions are configured")
+	}
+}
+
+func TestEngineRunSubmitsJobWhenConfigured(t *testing.T) {
+	client := &fakeClient{}
+	e := New(client, 0, &JobOptions{BackoffLimit: 2})
+
+	spec := &Spec{Namespace: "default"}
+	step := &Step{Name: "build"}
+
+	if _, err := e.Run(context.Background(), spec, step, ioutil.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.job == nil {
+		t.Fatal("want engine to create a job when JobOptions are configured")
+	}
+	if client.pod != nil {
+		t.Fatal("want engine not to create a bare pod when JobOptions are configured")
+	}
+	if client.job.Spec.BackoffLimit == nil || *client.job.Spec.BackoffLimit != 2 {
+		t.Errorf("want backoff limit 2, got %v", client.job.Spec.BackoffLimit)
+	}
+}
+
+func TestEngineRunAppliesPodScheduling(t *testing.T) {
+	client := &fakeClient{}
+	e := New(client, 0, nil)
+
+	spec := &Spec{
+		Namespace: "default",
+		Schedule: &PodScheduling{
+			NodeSelector:       map[string]string{"pool": "gpu"},
+			ServiceAccountName: "ci",
+		},
+	}
+	step := &Step{Name: "build"}
+
+	if _, err := e.Run(context.Background(), spec, step, ioutil.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.pod.Spec.NodeSelector["pool"]; got != "gpu" {
+		t.Errorf("want node selector pool=gpu on the submitted pod, got %q", got)
+	}
+	if got := client.pod.Spec.ServiceAccountName; got != "ci" {
+		t.Errorf("want service account ci on the submitted pod, got %q", got)
+	}
+}
+
+func TestEngineRunAppliesStepResources(t *testing.T) {
+	client := &fakeClient{}
+	e := New(client, 0, nil)
+
+	spec := &Spec{Namespace: "default"}
+	step := &Step{
+		Name:      "build",
+		Resources: Resources{Limits: ResourceObject{CPU: 1000, Memory: 1024}},
+	}
+
+	if _, err := e.Run(context.Background(), spec, step, ioutil.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.pod.Spec.Containers) != 1 {
+		t.Fatalf("want 1 container on the submitted pod, got %d", len(client.pod.Spec.Containers))
+	}
+	limit := client.pod.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU]
+	if limit.MilliValue() != 1000 {
+		t.Errorf("want cpu limit 1000m on the submitted pod's container, got %v", limit.MilliValue())
+	}
+}
+
+func TestEngineRunReturnsPodExitCode(t *testing.T) {
+	client := &fakeClient{result: &PodResult{ExitCode: 137, OOMKilled: true}}
+	e := New(client, 0, nil)
+
+	spec := &Spec{Namespace: "default"}
+	step := &Step{Name: "build"}
+
+	state, err := e.Run(context.Background(), spec, step, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !state.Exited {
+		t.Error("want state to report the pod as exited")
+	}
+	if state.ExitCode != 137 {
+		t.Errorf("want exit code 137 from the completed pod, got %d", state.ExitCode)
+	}
+	if !state.OOMKilled {
+		t.Error("want state to report the pod as OOM killed")
+	}
+}
+
+func TestEngineRunReturnsJobExitCode(t *testing.T) {
+	client := &fakeClient{result: &PodResult{ExitCode: 1}}
+	e := New(client, 0, &JobOptions{BackoffLimit: 2})
+
+	spec := &Spec{Namespace: "default"}
+	step := &Step{Name: "build"}
+
+	state, err := e.Run(context.Background(), spec, step, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.ExitCode != 1 {
+		t.Errorf("want exit code 1 from the completed job, got %d", state.ExitCode)
+	}
+}
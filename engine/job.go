@@ -0,0 +1,59 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JobOptions configures the Kubernetes Job-based execution backend.
+// When set, the engine submits each step as its own batchv1.Job
+// instead of a bare Pod; a stage is not submitted as a single Job.
+type JobOptions struct {
+	// BackoffLimit is the number of retries before the Job is
+	// considered failed.
+	BackoffLimit int32
+
+	// TTLSecondsAfterFinished is how long a finished Job, and its
+	// pods, are kept around before the Kubernetes TTL controller
+	// garbage collects them. Zero leaves it unset on the Job.
+	TTLSecondsAfterFinished int32
+
+	// ActiveDeadlineSeconds bounds the total time the Job is
+	// allowed to run before it is terminated. Zero leaves it unset
+	// on the Job.
+	ActiveDeadlineSeconds int64
+}
+
+// toJob wraps template in a batchv1.Job named name, configured per
+// opts, so the caller can submit it in place of a bare pod. template
+// is modified in place: a Job's pod template must not restart the
+// container itself, since the Job controller is what drives retries.
+func toJob(name string, template *corev1.PodTemplateSpec, opts *JobOptions) *batchv1.Job {
+	template.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: template.Namespace,
+			Labels:    template.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			Template:     *template,
+			BackoffLimit: &opts.BackoffLimit,
+		},
+	}
+	if opts.TTLSecondsAfterFinished > 0 {
+		ttl := opts.TTLSecondsAfterFinished
+		job.Spec.TTLSecondsAfterFinished = &ttl
+	}
+	if opts.ActiveDeadlineSeconds > 0 {
+		deadline := opts.ActiveDeadlineSeconds
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	return job
+}
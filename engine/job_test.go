@@ -0,0 +1,54 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestToJob(t *testing.T) {
+	template := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyAlways,
+		},
+	}
+	opts := &JobOptions{
+		BackoffLimit:            3,
+		TTLSecondsAfterFinished: 300,
+		ActiveDeadlineSeconds:   600,
+	}
+
+	job := toJob("stage-1", template, opts)
+
+	if job.Name != "stage-1" {
+		t.Errorf("want job name %q, got %q", "stage-1", job.Name)
+	}
+	if job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("want restart policy %q, got %q", corev1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+	}
+	if job.Spec.BackoffLimit == nil || *job.Spec.BackoffLimit != 3 {
+		t.Errorf("want backoff limit 3, got %v", job.Spec.BackoffLimit)
+	}
+	if job.Spec.TTLSecondsAfterFinished == nil || *job.Spec.TTLSecondsAfterFinished != 300 {
+		t.Errorf("want ttl seconds after finished 300, got %v", job.Spec.TTLSecondsAfterFinished)
+	}
+	if job.Spec.ActiveDeadlineSeconds == nil || *job.Spec.ActiveDeadlineSeconds != 600 {
+		t.Errorf("want active deadline seconds 600, got %v", job.Spec.ActiveDeadlineSeconds)
+	}
+}
+
+func TestToJobZeroValueOptOuts(t *testing.T) {
+	template := &corev1.PodTemplateSpec{}
+	job := toJob("stage-1", template, &JobOptions{})
+
+	if job.Spec.TTLSecondsAfterFinished != nil {
+		t.Error("want ttl seconds after finished unset when zero")
+	}
+	if job.Spec.ActiveDeadlineSeconds != nil {
+		t.Error("want active deadline seconds unset when zero")
+	}
+}
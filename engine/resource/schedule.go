@@ -0,0 +1,46 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package resource
+
+// Toleration mirrors the Kubernetes toleration, allowing a stage
+// pod to schedule onto nodes with a matching taint.
+type Toleration struct {
+	Key      string `json:"key,omitempty"      yaml:"key,omitempty"`
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value    string `json:"value,omitempty"    yaml:"value,omitempty"`
+	Effect   string `json:"effect,omitempty"   yaml:"effect,omitempty"`
+}
+
+// TopologySpreadConstraint mirrors the Kubernetes topology spread
+// constraint, controlling how stage pods are spread across a
+// topology domain such as zone or hostname.
+type TopologySpreadConstraint struct {
+	MaxSkew           int32             `json:"max_skew,omitempty"           yaml:"max_skew,omitempty"`
+	TopologyKey       string            `json:"topology_key,omitempty"       yaml:"topology_key,omitempty"`
+	WhenUnsatisfiable string            `json:"when_unsatisfiable,omitempty" yaml:"when_unsatisfiable,omitempty"`
+	LabelSelector     map[string]string `json:"label_selector,omitempty"     yaml:"label_selector,omitempty"`
+}
+
+// Affinity mirrors the subset of the Kubernetes affinity spec that
+// pipelines can express: node affinity, pod affinity, and pod
+// anti-affinity rules.
+type Affinity struct {
+	NodeAffinity    *NodeAffinity `json:"node_affinity,omitempty"     yaml:"node_affinity,omitempty"`
+	PodAffinity     *PodAffinity  `json:"pod_affinity,omitempty"      yaml:"pod_affinity,omitempty"`
+	PodAntiAffinity *PodAffinity  `json:"pod_anti_affinity,omitempty" yaml:"pod_anti_affinity,omitempty"`
+}
+
+// NodeAffinity describes node affinity scheduling rules for a pod.
+type NodeAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution  map[string]string   `json:"required,omitempty"  yaml:"required,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []map[string]string `json:"preferred,omitempty" yaml:"preferred,omitempty"`
+}
+
+// PodAffinity describes pod affinity, or anti-affinity, scheduling
+// rules for a pod.
+type PodAffinity struct {
+	TopologyKey   string            `json:"topology_key,omitempty"   yaml:"topology_key,omitempty"`
+	LabelSelector map[string]string `json:"label_selector,omitempty" yaml:"label_selector,omitempty"`
+}
@@ -0,0 +1,27 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"github.com/drone/runner-go/pipeline/runtime"
+)
+
+// PodResult reports the terminal status of a step's container, once
+// the pod Kubernetes ran it in has finished.
+type PodResult struct {
+	ExitCode  int
+	OOMKilled bool
+}
+
+// toState converts result to the runtime.State the pipeline engine
+// interface expects, so a step's actual exit code (and whether it
+// was OOM killed) is reported instead of an assumed success.
+func toState(result *PodResult) *runtime.State {
+	return &runtime.State{
+		ExitCode:  result.ExitCode,
+		Exited:    true,
+		OOMKilled: result.OOMKilled,
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodScheduling controls where the stage pod is allowed to run,
+// letting operators pin pods to specific nodes, zones, or node
+// pools on a multi-node cluster.
+type PodScheduling struct {
+	NodeSelector              map[string]string
+	Tolerations               []Toleration
+	Affinity                  *Affinity
+	TopologySpreadConstraints []TopologySpreadConstraint
+	RuntimeClassName          string
+	PriorityClassName         string
+	ServiceAccountName        string
+}
+
+// Toleration describes a pod toleration for a node taint.
+type Toleration struct {
+	Key      string
+	Operator string
+	Value    string
+	Effect   string
+}
+
+// TopologySpreadConstraint describes how pods should be spread
+// across a topology domain.
+type TopologySpreadConstraint struct {
+	MaxSkew           int32
+	TopologyKey       string
+	WhenUnsatisfiable string
+	LabelSelector     map[string]string
+}
+
+// Affinity describes node and pod (anti-)affinity rules for the
+// stage pod.
+type Affinity struct {
+	NodeAffinity    *NodeAffinity
+	PodAffinity     *PodAffinity
+	PodAntiAffinity *PodAffinity
+}
+
+// NodeAffinity describes node affinity scheduling rules.
+type NodeAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution  map[string]string
+	PreferredDuringSchedulingIgnoredDuringExecution []map[string]string
+}
+
+// PodAffinity describes pod affinity, or anti-affinity, scheduling
+// rules.
+type PodAffinity struct {
+	TopologyKey   string
+	LabelSelector map[string]string
+}
+
+// ApplySchedule stores the pod scheduling configuration on the
+// spec, with any non-zero field on override replacing the value
+// the pipeline YAML declared, except tolerations, which are added to
+// the pipeline's own.
+func ApplySchedule(spec *Spec, override *PodScheduling) {
+	if override == nil {
+		return
+	}
+	if spec.Schedule == nil {
+		spec.Schedule = override
+		return
+	}
+	if len(override.NodeSelector) > 0 {
+		spec.Schedule.NodeSelector = override.NodeSelector
+	}
+	if len(override.Tolerations) > 0 {
+		spec.Schedule.Tolerations = append(spec.Schedule.Tolerations, override.Tolerations...)
+	}
+	if override.Affinity != nil {
+		spec.Schedule.Affinity = override.Affinity
+	}
+	if len(override.TopologySpreadConstraints) > 0 {
+		spec.Schedule.TopologySpreadConstraints = override.TopologySpreadConstraints
+	}
+	if override.RuntimeClassName != "" {
+		spec.Schedule.RuntimeClassName = override.RuntimeClassName
+	}
+	if override.PriorityClassName != "" {
+		spec.Schedule.PriorityClassName = override.PriorityClassName
+	}
+	if override.ServiceAccountName != "" {
+		spec.Schedule.ServiceAccountName = override.ServiceAccountName
+	}
+}
+
+// applyPodScheduling copies s onto podSpec, translating the engine's
+// scheduling types into their corev1 equivalents. Without this, a
+// spec's Schedule is recorded but never reaches the pod Kubernetes
+// actually places.
+func applyPodScheduling(podSpec *corev1.PodSpec, s *PodScheduling) {
+	if s == nil {
+		return
+	}
+	podSpec.NodeSelector = s.NodeSelector
+	podSpec.ServiceAccountName = s.ServiceAccountName
+	podSpec.PriorityClassName = s.PriorityClassName
+	if s.RuntimeClassName != "" {
+		podSpec.RuntimeClassName = &s.RuntimeClassName
+	}
+	for _, t := range s.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, corev1.Toleration{
+			Key:      t.Key,
+			Operator: corev1.TolerationOperator(t.Operator),
+			Value:    t.Value,
+			Effect:   corev1.TaintEffect(t.Effect),
+		})
+	}
+	for _, c := range s.TopologySpreadConstraints {
+		podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           c.MaxSkew,
+			TopologyKey:       c.TopologyKey,
+			WhenUnsatisfiable: corev1.UnsatisfiableConstraintAction(c.WhenUnsatisfiable),
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: c.LabelSelector},
+		})
+	}
+	podSpec.Affinity = toAffinity(s.Affinity)
+}
+
+// toAffinity converts the engine's simplified affinity rules to
+// their corev1 equivalent. A single key/value pair is expressed as
+// a node selector requirement with the "In" operator, and a pod
+// (anti-)affinity rule as a single required term, since that is all
+// the engine's Affinity type is able to express.
+func toAffinity(a *Affinity) *corev1.Affinity {
+	if a == nil {
+		return nil
+	}
+	out := &corev1.Affinity{}
+	if a.NodeAffinity != nil {
+		out.NodeAffinity = toNodeAffinity(a.NodeAffinity)
+	}
+	if a.PodAffinity != nil {
+		out.PodAffinity = &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{toPodAffinityTerm(a.PodAffinity)},
+		}
+	}
+	if a.PodAntiAffinity != nil {
+		out.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{toPodAffinityTerm(a.PodAntiAffinity)},
+		}
+	}
+	return out
+}
+
+func toNodeAffinity(a *NodeAffinity) *corev1.NodeAffinity {
+	out := &corev1.NodeAffinity{}
+	if len(a.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+		out.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{toNodeSelectorTerm(a.RequiredDuringSchedulingIgnoredDuringExecution)},
+		}
+	}
+	for _, m := range a.PreferredDuringSchedulingIgnoredDuringExecution {
+		out.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			out.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.PreferredSchedulingTerm{
+				Weight:     1,
+				Preference: toNodeSelectorTerm(m),
+			},
+		)
+	}
+	return out
+}
+
+func toNodeSelectorTerm(m map[string]string) corev1.NodeSelectorTerm {
+	exprs := make([]corev1.NodeSelectorRequirement, 0, len(m))
+	for k, v := range m {
+		exprs = append(exprs, corev1.NodeSelectorRequirement{
+			Key:      k,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{v},
+		})
+	}
+	return corev1.NodeSelectorTerm{MatchExpressions: exprs}
+}
+
+func toPodAffinityTerm(a *PodAffinity) corev1.PodAffinityTerm {
+	return corev1.PodAffinityTerm{
+		TopologyKey:   a.TopologyKey,
+		LabelSelector: &metav1.LabelSelector{MatchLabels: a.LabelSelector},
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"github.com/drone/runner-go/pipeline/runtime"
+)
+
+// Spec is the compiled pipeline specification executed by the
+// engine.
+type Spec struct {
+	Namespace string
+	Steps     []*Step
+	Schedule  *PodScheduling
+}
+
+// Step is a single step of a Spec.
+type Step struct {
+	Name      string
+	DependsOn []string
+	RunPolicy runtime.RunPolicy
+	ErrPolicy runtime.ErrPolicy
+	Resources Resources
+}
+
+// SecretVar references a secret to be injected into a step as an
+// environment variable.
+type SecretVar struct {
+	Name string
+	Env  string
+}
+
+// PullPolicy controls when a step's container image is pulled.
+type PullPolicy int
+
+// Supported pull policies.
+const (
+	PullDefault PullPolicy = iota
+	PullAlways
+	PullIfNotExists
+	PullNever
+)
+
+// Resources holds the resource limits and requests for a step's
+// container.
+type Resources struct {
+	Limits   ResourceObject
+	Requests ResourceObject
+}
+
+// ResourceObject holds a single set of CPU, memory, GPU and
+// ephemeral storage quantities.
+type ResourceObject struct {
+	CPU              int64
+	Memory           int64
+	GPU              int64
+	EphemeralStorage int64
+}